@@ -0,0 +1,154 @@
+package joboptions
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ttab/joboptions/parser"
+)
+
+// LimitKind identifies which DecoderOptions limit was exceeded.
+type LimitKind = parser.LimitKind
+
+const (
+	LimitMaxDepth             = parser.LimitMaxDepth
+	LimitMaxStringLength      = parser.LimitMaxStringLength
+	LimitMaxBinaryLength      = parser.LimitMaxBinaryLength
+	LimitMaxArrayElements     = parser.LimitMaxArrayElements
+	LimitMaxDictionaryEntries = parser.LimitMaxDictionaryEntries
+)
+
+// LimitError is returned by Decoder when a configured DecoderOptions limit
+// is exceeded. It identifies the limit that was hit and the line on which
+// that happened.
+type LimitError = parser.LimitError
+
+// DecoderOptions bounds the resources a Decoder will use while reading a
+// joboptions payload, guarding against pathologically deep or huge input
+// from untrusted parties. A zero value leaves the corresponding aspect
+// unbounded, matching the behaviour of Parse.
+type DecoderOptions struct {
+	// MaxDepth bounds how many levels of nested dictionaries and arrays
+	// may be read.
+	MaxDepth int
+	// MaxStringLength bounds the number of bytes between the
+	// parentheses of a string value.
+	MaxStringLength int
+	// MaxBinaryLength bounds the number of bytes between the angle
+	// brackets of a binary value.
+	MaxBinaryLength int
+	// MaxArrayElements bounds the number of elements in an array.
+	MaxArrayElements int
+	// MaxDictionaryEntries bounds the number of entries in a dictionary;
+	// a key that appears more than once counts each time.
+	MaxDictionaryEntries int
+}
+
+// Decoder reads parameter dictionaries from a joboptions payload. Unlike
+// Parse it reads from an io.Reader in chunks, and can be bounded with
+// DecoderOptions, so it's suitable for untrusted input.
+type Decoder struct {
+	s    *parser.Scanner
+	opts DecoderOptions
+}
+
+// NewDecoder returns a Decoder that reads from r, enforcing opts.
+func NewDecoder(r io.Reader, opts DecoderOptions) *Decoder {
+	return &Decoder{
+		s: parser.NewReaderScanner(r, parser.ScanLimits{
+			MaxStringLength: opts.MaxStringLength,
+			MaxBinaryLength: opts.MaxBinaryLength,
+		}),
+		opts: opts,
+	}
+}
+
+// Decode reads the whole payload and binds it onto v, honouring the
+// Decoder's DecoderOptions. v can be a *Parameters, or a pointer to a
+// struct tagged the same way Unmarshal expects.
+func (d *Decoder) Decode(v any) error {
+	params, err := d.readAll()
+	if err != nil {
+		return err
+	}
+
+	if pp, ok := v.(*Parameters); ok {
+		*pp = params
+
+		return nil
+	}
+
+	return decodeParameters(params, v)
+}
+
+func (d *Decoder) readAll() (Parameters, error) {
+	params := make(Parameters)
+
+	for {
+		name, dict, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		params[name] = dict
+	}
+
+	return params, nil
+}
+
+// Token reads the next top-level named parameter dictionary, returning
+// io.EOF once the payload is exhausted. This lets callers that need to
+// process many concatenated joboptions payloads do so one dictionary at a
+// time, instead of having to buffer them all in memory.
+func (d *Decoder) Token() (string, Dictionary, error) {
+	var header headerAccumulator
+
+	for {
+		if !d.s.Scan() {
+			if err := d.s.Err(); err != nil {
+				return "", Dictionary{}, fmt.Errorf("parse data: %w", err)
+			}
+
+			return "", Dictionary{}, io.EOF
+		}
+
+		t := d.s.Token()
+		line := d.s.Line()
+
+		header.resetIfGap(line)
+
+		if t.Type == parser.TypeComment {
+			header.add(line, string(t.Value))
+			continue
+		}
+
+		if t.Type != parser.TypeStartDictionary {
+			return "", Dictionary{}, d.s.UnexpectedTokenError(parser.TypeStartDictionary, t)
+		}
+
+		dict, err := parseDictionary(d.s, limits{opts: d.opts})
+		if err != nil {
+			return "", Dictionary{}, fmt.Errorf("parse parameter dictionary: %w", err)
+		}
+
+		if lines := header.take(); len(lines) > 0 {
+			dict.HeaderComments = lines
+		}
+
+		if !d.s.Scan() {
+			return "", Dictionary{}, d.s.WrapErrorf("parse parameter dictionary name")
+		}
+
+		t = d.s.Token()
+		if t.Type != parser.TypeIdentifier {
+			return "", Dictionary{}, d.s.UnexpectedTokenError(parser.TypeIdentifier, t)
+		}
+
+		return string(t.Value), dict, nil
+	}
+}
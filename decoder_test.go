@@ -0,0 +1,186 @@
+package joboptions_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ttab/joboptions"
+)
+
+func TestDecoder_Decode(t *testing.T) {
+	data := `<<
+  /AntiAliasGrayImages true
+  /ColorSettingsFile (sRGB)
+>>
+setdistillerparams
+`
+
+	dec := joboptions.NewDecoder(strings.NewReader(data), joboptions.DecoderOptions{})
+
+	var got joboptions.Parameters
+
+	err := dec.Decode(&got)
+	must(t, err, "decode")
+
+	d, ok := got["setdistillerparams"]
+	if !ok {
+		t.Fatalf("missing setdistillerparams dictionary")
+	}
+
+	if len(d.Values) != 2 {
+		t.Errorf("got %d values, want 2", len(d.Values))
+	}
+}
+
+func TestDecoder_MaxDepth(t *testing.T) {
+	data := `<<
+  /Nested <<
+    /Deep true
+  >>
+>>
+setdistillerparams
+`
+
+	dec := joboptions.NewDecoder(strings.NewReader(data), joboptions.DecoderOptions{
+		MaxDepth: 1,
+	})
+
+	var got joboptions.Parameters
+
+	err := dec.Decode(&got)
+
+	var limitErr *joboptions.LimitError
+
+	if !errors.As(err, &limitErr) {
+		t.Fatalf("got %v, want a *LimitError", err)
+	}
+
+	if limitErr.Kind != joboptions.LimitMaxDepth {
+		t.Errorf("got limit kind %v, want MaxDepth", limitErr.Kind)
+	}
+}
+
+func TestDecoder_MaxStringLength(t *testing.T) {
+	data := `<<
+  /Title (abc)
+>>
+setdistillerparams
+`
+
+	for _, tc := range []struct {
+		name    string
+		maxLen  int
+		wantErr bool
+	}{
+		{name: "under the limit", maxLen: 4, wantErr: false},
+		{name: "exactly at the limit", maxLen: 3, wantErr: false},
+		{name: "over the limit", maxLen: 2, wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dec := joboptions.NewDecoder(strings.NewReader(data), joboptions.DecoderOptions{
+				MaxStringLength: tc.maxLen,
+			})
+
+			var got joboptions.Parameters
+
+			err := dec.Decode(&got)
+
+			var limitErr *joboptions.LimitError
+
+			gotErr := errors.As(err, &limitErr)
+			if gotErr != tc.wantErr {
+				t.Fatalf("got err %v, wantErr %v", err, tc.wantErr)
+			}
+
+			if gotErr && limitErr.Kind != joboptions.LimitMaxStringLength {
+				t.Errorf("got limit kind %v, want MaxStringLength", limitErr.Kind)
+			}
+		})
+	}
+}
+
+func TestDecoder_MaxBinaryLength(t *testing.T) {
+	data := `<<
+  /Data <AABBCC>
+>>
+setdistillerparams
+`
+
+	for _, tc := range []struct {
+		name    string
+		maxLen  int
+		wantErr bool
+	}{
+		{name: "under the limit", maxLen: 7, wantErr: false},
+		{name: "exactly at the limit", maxLen: 6, wantErr: false},
+		{name: "over the limit", maxLen: 5, wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dec := joboptions.NewDecoder(strings.NewReader(data), joboptions.DecoderOptions{
+				MaxBinaryLength: tc.maxLen,
+			})
+
+			var got joboptions.Parameters
+
+			err := dec.Decode(&got)
+
+			var limitErr *joboptions.LimitError
+
+			gotErr := errors.As(err, &limitErr)
+			if gotErr != tc.wantErr {
+				t.Fatalf("got err %v, wantErr %v", err, tc.wantErr)
+			}
+
+			if gotErr && limitErr.Kind != joboptions.LimitMaxBinaryLength {
+				t.Errorf("got limit kind %v, want MaxBinaryLength", limitErr.Kind)
+			}
+		})
+	}
+}
+
+func TestDecoder_TokenCommentBlankLineBreaksAttachment(t *testing.T) {
+	data := "% a header comment\n\n<<\n  /Foo true\n>>\nsetdistillerparams\n"
+
+	dec := joboptions.NewDecoder(strings.NewReader(data), joboptions.DecoderOptions{})
+
+	name, dict, err := dec.Token()
+	must(t, err, "read token")
+
+	if name != "setdistillerparams" {
+		t.Fatalf("got name %q, want setdistillerparams", name)
+	}
+
+	if len(dict.HeaderComments) != 0 {
+		t.Errorf("got HeaderComments %v, want none", dict.HeaderComments)
+	}
+}
+
+func TestDecoder_Token(t *testing.T) {
+	data := `<<
+  /A true
+>>
+setdistillerparams
+<<
+  /B false
+>>
+setpagedevice
+`
+
+	dec := joboptions.NewDecoder(strings.NewReader(data), joboptions.DecoderOptions{})
+
+	var names []string
+
+	for {
+		name, _, err := dec.Token()
+		if err != nil {
+			break
+		}
+
+		names = append(names, name)
+	}
+
+	if len(names) != 2 || names[0] != "setdistillerparams" || names[1] != "setpagedevice" {
+		t.Errorf("got %v, want [setdistillerparams setpagedevice]", names)
+	}
+}
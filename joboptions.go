@@ -51,8 +51,22 @@ type Value struct {
 	// Binary data is represented as a hex encoded string in a joboptions
 	// file, this is the decoded version of that data.
 	Binary []byte `json:"binary,omitempty"`
+	// LeadingComments are the "%" comment lines that immediately preceded
+	// this value's key, with no blank line in between.
+	LeadingComments []string `json:"leadingComments,omitempty"`
+	// TrailingComment is a "%" comment that followed this value on the
+	// same line.
+	TrailingComment string `json:"trailingComment,omitempty"`
+	// Position is where this value starts in the source payload it was
+	// parsed from. It's the zero Position for values that weren't
+	// produced by Parse or a Decoder, e.g. ones built up by hand or by
+	// Marshal from a Go struct.
+	Position Position `json:"position"`
 }
 
+// Position identifies a location in a joboptions payload.
+type Position = parser.Position
+
 func (v Value) StringFromUTF16() (string, error) {
 	if v.Type != ValueBinary {
 		return "", fmt.Errorf("not a binary value")
@@ -72,7 +86,47 @@ func (v Value) StringFromUTF16() (string, error) {
 }
 
 // Dictionary is a set of Values keyed by a literal.
-type Dictionary map[Literal]Value
+type Dictionary struct {
+	Values Values `json:"values"`
+	// Entries holds the same key/value pairs as Values, but in the order
+	// they appeared in the source payload and with the source position
+	// of each key and value. Values loses that ordering because it's a
+	// Go map, so callers that need to iterate deterministically or
+	// report precise diagnostics should use Entries (or Keys) instead.
+	// A key that appears more than once has an entry for each occurrence,
+	// while Values only keeps the last one.
+	Entries []DictionaryEntry `json:"entries,omitempty"`
+	// HeaderComments are the "%" comment lines that preceded this
+	// dictionary, e.g. a block identifying a Distiller preset's name and
+	// version. Only populated for the top-level dictionaries in a
+	// Parameters set.
+	HeaderComments []string `json:"headerComments,omitempty"`
+}
+
+// Keys returns the Dictionary's keys in the order they appeared in the
+// source payload, taken from Entries. Keys that are only in Values, e.g. in a
+// hand-built Dictionary with no Entries, are not included.
+func (d Dictionary) Keys() []Literal {
+	keys := make([]Literal, len(d.Entries))
+
+	for i, e := range d.Entries {
+		keys[i] = e.Key
+	}
+
+	return keys
+}
+
+// DictionaryEntry pairs a Dictionary key and value with the source position
+// of each, for order-preserving iteration and diagnostics.
+type DictionaryEntry struct {
+	Key      Literal  `json:"key"`
+	Value    Value    `json:"value"`
+	KeyPos   Position `json:"keyPos"`
+	ValuePos Position `json:"valuePos"`
+}
+
+// Values is a set of Values keyed by a literal.
+type Values map[Literal]Value
 
 // Parameters is a collection of named dictionaries. This is the top level
 // representation of a joboptions file.
@@ -84,20 +138,90 @@ type Parameters map[string]Dictionary
 func Parse(data []byte) (Parameters, error) {
 	p := parser.NewScanner(data)
 
+	return parse(p, limits{})
+}
+
+// limits threads the configured DecoderOptions and the current nesting
+// depth through a parse, so parseDictionary, parseArray and parseValue can
+// enforce them without a preloaded recursion-depth parameter at every call
+// site.
+type limits struct {
+	opts  DecoderOptions
+	depth int
+}
+
+// descend returns the limits for one level deeper nesting, or a
+// *LimitError if that would exceed MaxDepth.
+func (l limits) descend(line int) (limits, error) {
+	next := l
+	next.depth++
+
+	if l.opts.MaxDepth > 0 && next.depth > l.opts.MaxDepth {
+		return limits{}, &LimitError{Kind: LimitMaxDepth, Line: line}
+	}
+
+	return next, nil
+}
+
+// headerAccumulator gathers a run of top-level "%" comment lines that
+// precede a dictionary, e.g. a block identifying a Distiller preset's name
+// and version. A blank line breaks the run, so comments separated from the
+// dictionary they'd otherwise document don't get attached to it.
+type headerAccumulator struct {
+	lines []string
+	line  int
+}
+
+// resetIfGap drops any accumulated lines if line isn't immediately after
+// the last accumulated one.
+func (h *headerAccumulator) resetIfGap(line int) {
+	if len(h.lines) > 0 && line > h.line+1 {
+		h.lines = nil
+	}
+}
+
+func (h *headerAccumulator) add(line int, text string) {
+	h.lines = append(h.lines, text)
+	h.line = line
+}
+
+// take returns the accumulated lines, if any, and clears the accumulator.
+func (h *headerAccumulator) take() []string {
+	lines := h.lines
+	h.lines = nil
+
+	return lines
+}
+
+func parse(p *parser.Scanner, lim limits) (Parameters, error) {
 	params := make(Parameters)
 
+	var header headerAccumulator
+
 	for p.Scan() {
 		t := p.Token()
+		line := p.Line()
+
+		header.resetIfGap(line)
+
+		if t.Type == parser.TypeComment {
+			header.add(line, string(t.Value))
+			continue
+		}
 
 		if t.Type != parser.TypeStartDictionary {
 			return nil, p.UnexpectedTokenError(parser.TypeStartDictionary, t)
 		}
 
-		d, err := parseDictionary(p)
+		d, err := parseDictionary(p, lim)
 		if err != nil {
 			return nil, fmt.Errorf("parse parameter dictionary: %w", err)
 		}
 
+		if lines := header.take(); len(lines) > 0 {
+			d.HeaderComments = lines
+		}
+
 		if !p.Scan() {
 			return nil, p.WrapErrorf("parse parameter dictionary name")
 		}
@@ -117,40 +241,111 @@ func Parse(data []byte) (Parameters, error) {
 	return params, nil
 }
 
-func parseDictionary(p *parser.Scanner) (Dictionary, error) {
-	d := make(Dictionary)
+func parseDictionary(p *parser.Scanner, lim limits) (Dictionary, error) {
+	lim, err := lim.descend(p.Line())
+	if err != nil {
+		return Dictionary{}, err
+	}
+
+	d := Dictionary{Values: make(Values)}
+
+	var (
+		pending       []string
+		pendingLine   int
+		lastKey       Literal
+		lastEntry     int
+		haveLastValue bool
+		lastValueLine int
+		entries       int
+	)
 
 	for p.Scan() {
 		t := p.Token()
+		line := p.Line()
+
+		if t.Type == parser.TypeComment {
+			text := string(t.Value)
+
+			if haveLastValue && line == lastValueLine {
+				v := d.Values[lastKey]
+				v.TrailingComment = text
+				d.Values[lastKey] = v
+
+				d.Entries[lastEntry].Value.TrailingComment = text
+
+				continue
+			}
+
+			if len(pending) > 0 && line > pendingLine+1 {
+				pending = nil
+			}
+
+			pending = append(pending, text)
+			pendingLine = line
+
+			continue
+		}
 
 		if t.Type == parser.TypeEndDictionary {
 			return d, nil
 		}
 
 		if t.Type != parser.TypeLiteral {
-			return nil, p.UnexpectedTokenError(parser.TypeLiteral, t)
+			return Dictionary{}, p.UnexpectedTokenError(parser.TypeLiteral, t)
+		}
+
+		if len(pending) > 0 && line > pendingLine+1 {
+			pending = nil
 		}
 
 		key := Literal(t.Value)
+		keyPos := t.Position()
+
+		entries++
+
+		if lim.opts.MaxDictionaryEntries > 0 && entries > lim.opts.MaxDictionaryEntries {
+			return Dictionary{}, &LimitError{Kind: LimitMaxDictionaryEntries, Line: line}
+		}
 
 		if !p.Scan() {
-			return nil, p.WrapErrorf("parse dictionary value")
+			return Dictionary{}, p.WrapErrorf("parse dictionary value")
 		}
 
 		t = p.Token()
 
-		value, err := parseValue(p, t)
+		value, err := parseValue(p, t, lim)
 		if err != nil {
-			return nil, fmt.Errorf("parse value of %q: %w", key, err)
+			return Dictionary{}, fmt.Errorf("parse value of %q: %w", key, err)
+		}
+
+		if len(pending) > 0 {
+			value.LeadingComments = pending
+			pending = nil
 		}
 
-		d[key] = value
+		d.Values[key] = value
+		d.Entries = append(d.Entries, DictionaryEntry{
+			Key:      key,
+			Value:    value,
+			KeyPos:   keyPos,
+			ValuePos: value.Position,
+		})
+
+		lastKey = key
+		lastEntry = len(d.Entries) - 1
+		haveLastValue = true
+		lastValueLine = p.Line()
 	}
 
-	return nil, io.ErrUnexpectedEOF
+	return Dictionary{}, io.ErrUnexpectedEOF
 }
 
-func parseArray(p *parser.Scanner) (Value, error) {
+func parseArray(p *parser.Scanner, lim limits) (Value, error) {
+	lim, err := lim.descend(p.Line())
+	if err != nil {
+		return Value{}, err
+	}
+
 	var a []Value
 
 	var idx int
@@ -164,7 +359,11 @@ func parseArray(p *parser.Scanner) (Value, error) {
 			}, nil
 		}
 
-		value, err := parseValue(p, t)
+		if lim.opts.MaxArrayElements > 0 && idx >= lim.opts.MaxArrayElements {
+			return Value{}, &LimitError{Kind: LimitMaxArrayElements, Line: p.Line()}
+		}
+
+		value, err := parseValue(p, t, lim)
 		if err != nil {
 			return Value{}, fmt.Errorf("parse value at index %d: %w", idx, err)
 		}
@@ -177,7 +376,18 @@ func parseArray(p *parser.Scanner) (Value, error) {
 	return Value{}, io.ErrUnexpectedEOF
 }
 
-func parseValue(p *parser.Scanner, t parser.Token) (Value, error) {
+func parseValue(p *parser.Scanner, t parser.Token, lim limits) (Value, error) {
+	v, err := parseValueData(p, t, lim)
+	if err != nil {
+		return Value{}, err
+	}
+
+	v.Position = t.Position()
+
+	return v, nil
+}
+
+func parseValueData(p *parser.Scanner, t parser.Token, lim limits) (Value, error) {
 	switch t.Type {
 	case parser.TypeBoolean:
 		isTrue := bytes.Equal(t.Value, []byte("true"))
@@ -187,7 +397,7 @@ func parseValue(p *parser.Scanner, t parser.Token) (Value, error) {
 			Boolean: isTrue,
 		}, nil
 	case parser.TypeStartArray:
-		return parseArray(p)
+		return parseArray(p, lim)
 	case parser.TypeLiteral:
 		return Value{
 			Type:    ValueLiteral,
@@ -244,7 +454,7 @@ func parseValue(p *parser.Scanner, t parser.Token) (Value, error) {
 			Binary: cpy,
 		}, nil
 	case parser.TypeStartDictionary:
-		d, err := parseDictionary(p)
+		d, err := parseDictionary(p, lim)
 		if err != nil {
 			return Value{}, err
 		}
@@ -50,6 +50,39 @@ func TestParse_Valid(t *testing.T) {
 	}
 }
 
+func TestParse_CommentBlankLineBreaksAttachment(t *testing.T) {
+	data := `% a header comment
+
+<<
+  % this comment has a blank line before the key it precedes
+
+  /Foo true
+>>
+setdistillerparams
+`
+
+	params, err := joboptions.Parse([]byte(data))
+	must(t, err, "parse input")
+
+	d, ok := params["setdistillerparams"]
+	if !ok {
+		t.Fatalf("missing setdistillerparams dictionary")
+	}
+
+	if len(d.HeaderComments) != 0 {
+		t.Errorf("got HeaderComments %v, want none", d.HeaderComments)
+	}
+
+	v, ok := d.Values["/Foo"]
+	if !ok {
+		t.Fatalf("missing /Foo value")
+	}
+
+	if len(v.LeadingComments) != 0 {
+		t.Errorf("got LeadingComments %v, want none", v.LeadingComments)
+	}
+}
+
 func must(t *testing.T, err error, format string, a ...any) {
 	t.Helper()
 
@@ -0,0 +1,267 @@
+package joboptions
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Marshal encodes v as a .joboptions payload. v can be a Parameters value,
+// or a pointer to (or plain value of) a struct tagged with `joboptions:"…"`
+// the same way Unmarshal expects, in which case each tagged field is
+// encoded as a top-level named dictionary.
+func Marshal(v any) ([]byte, error) {
+	params, err := toParameters(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	enc := NewEncoder(&buf)
+
+	if err := enc.Encode(params); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func toParameters(v any) (Parameters, error) {
+	if p, ok := v.(Parameters); ok {
+		return p, nil
+	}
+
+	return structToParameters(v)
+}
+
+// Encoder writes joboptions values to an output stream.
+type Encoder struct {
+	w        io.Writer
+	indent   string
+	sortKeys bool
+	err      error
+}
+
+// NewEncoder returns a new Encoder that writes to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// SetIndent instructs the Encoder to pretty-print each subsequent Encode call
+// with the given indentation string for every level of nesting. An empty
+// indent (the default) still puts each dictionary entry on its own line, but
+// without any indentation.
+func (e *Encoder) SetIndent(indent string) {
+	e.indent = indent
+}
+
+// SetSortKeys instructs the Encoder to emit parameter names and dictionary
+// keys in sorted order. By default parameter names come in Go's unspecified
+// map order, and dictionary keys in the order given by orderedKeys. This is
+// needed to produce byte-stable output, e.g. for golden-file tests.
+func (e *Encoder) SetSortKeys(sort bool) {
+	e.sortKeys = sort
+}
+
+// Encode writes the .joboptions representation of p to the underlying
+// writer.
+func (e *Encoder) Encode(p Parameters) error {
+	names := make([]string, 0, len(p))
+	for name := range p {
+		names = append(names, name)
+	}
+
+	if e.sortKeys {
+		sort.Strings(names)
+	}
+
+	for i, name := range names {
+		if i > 0 {
+			e.writeByte('\n')
+		}
+
+		d := p[name]
+
+		for _, c := range d.HeaderComments {
+			e.writeString("%" + c)
+			e.writeByte('\n')
+		}
+
+		if err := e.writeDictionary(d, 0); err != nil {
+			return fmt.Errorf("write dictionary %q: %w", name, err)
+		}
+
+		e.writeByte('\n')
+		e.writeString(name)
+		e.writeByte('\n')
+	}
+
+	return e.err
+}
+
+func (e *Encoder) writeByte(b byte) {
+	if e.err != nil {
+		return
+	}
+
+	_, e.err = e.w.Write([]byte{b})
+}
+
+func (e *Encoder) writeString(s string) {
+	if e.err != nil {
+		return
+	}
+
+	_, e.err = io.WriteString(e.w, s)
+}
+
+// writeIndent starts a new line, adding per-depth indentation if pretty
+// printing is enabled via SetIndent.
+func (e *Encoder) writeIndent(depth int) {
+	e.writeByte('\n')
+
+	if e.indent != "" {
+		e.writeString(strings.Repeat(e.indent, depth))
+	}
+}
+
+// orderedKeys returns d's keys in the order they appeared in the source
+// payload, using d.Entries. Any key present in d.Values but not covered by
+// Entries, e.g. in a hand-built Dictionary with no Entries at all, falls
+// back to Go's unspecified map order and is appended after the ones
+// Entries accounts for.
+func orderedKeys(d Dictionary) []Literal {
+	keys := make([]Literal, 0, len(d.Values))
+	seen := make(map[Literal]bool, len(d.Entries))
+
+	for _, entry := range d.Entries {
+		if _, ok := d.Values[entry.Key]; !ok || seen[entry.Key] {
+			continue
+		}
+
+		keys = append(keys, entry.Key)
+		seen[entry.Key] = true
+	}
+
+	for k := range d.Values {
+		if !seen[k] {
+			keys = append(keys, k)
+		}
+	}
+
+	return keys
+}
+
+func (e *Encoder) writeDictionary(d Dictionary, depth int) error {
+	keys := orderedKeys(d)
+
+	if e.sortKeys {
+		sort.Slice(keys, func(i, j int) bool {
+			return keys[i] < keys[j]
+		})
+	}
+
+	e.writeString("<<")
+
+	for _, k := range keys {
+		v := d.Values[k]
+
+		for _, c := range v.LeadingComments {
+			e.writeIndent(depth + 1)
+			e.writeString("%" + c)
+		}
+
+		e.writeIndent(depth + 1)
+		e.writeString(string(k))
+		e.writeByte(' ')
+
+		if err := e.writeValue(v, depth+1); err != nil {
+			return fmt.Errorf("write value of %q: %w", k, err)
+		}
+
+		if v.TrailingComment != "" {
+			e.writeString(" %" + v.TrailingComment)
+		}
+	}
+
+	e.writeIndent(depth)
+	e.writeString(">>")
+
+	return e.err
+}
+
+func (e *Encoder) writeValue(v Value, depth int) error {
+	switch v.Type {
+	case ValueBoolean:
+		if v.Boolean {
+			e.writeString("true")
+		} else {
+			e.writeString("false")
+		}
+	case ValueInteger:
+		e.writeString(strconv.Itoa(v.Integer))
+	case ValueFloat:
+		e.writeString(formatFloat(v.Float))
+	case ValueLiteral:
+		e.writeString(string(v.Literal))
+	case ValueString:
+		e.writeByte('(')
+		e.writeString(escapeString(v.String))
+		e.writeByte(')')
+	case ValueBinary:
+		e.writeByte('<')
+		e.writeString(hex.EncodeToString(v.Binary))
+		e.writeByte('>')
+	case ValueArray:
+		e.writeByte('[')
+
+		for i, a := range v.Array {
+			if i > 0 {
+				e.writeByte(' ')
+			}
+
+			if err := e.writeValue(a, depth); err != nil {
+				return fmt.Errorf("write value at index %d: %w", i, err)
+			}
+		}
+
+		e.writeByte(']')
+	case ValueDictionary:
+		return e.writeDictionary(v.Dictionary, depth)
+	default:
+		return fmt.Errorf("unknown value type %v", v.Type)
+	}
+
+	return e.err
+}
+
+// formatFloat renders f the way a joboptions file expects: the shortest
+// decimal representation that round-trips, always with a decimal point so
+// it can't be mistaken for a ValueInteger.
+func formatFloat(f float64) string {
+	s := strconv.FormatFloat(f, 'f', -1, 64)
+
+	if !strings.Contains(s, ".") {
+		s += ".0"
+	}
+
+	return s
+}
+
+// escapeString prepares s to be written between the parentheses of a
+// joboptions string value. It reuses strconv.Quote to get Go-compatible
+// backslash escaping (Parse unquotes values with strconv.Unquote), and then
+// additionally escapes ')' as an octal sequence: the scanner has no concept
+// of nested or backslash-escaped parens, so any literal ')' byte would be
+// read as the end of the string.
+func escapeString(s string) string {
+	quoted := strconv.Quote(s)
+	inner := quoted[1 : len(quoted)-1]
+
+	return strings.ReplaceAll(inner, ")", `\051`)
+}
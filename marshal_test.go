@@ -0,0 +1,96 @@
+package joboptions_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/ttab/joboptions"
+)
+
+func TestRoundTrip(t *testing.T) {
+	validFiles, err := filepath.Glob("testdata/valid/*.joboptions")
+	must(t, err, "glob for valid files")
+
+	// Marshal doesn't preserve the original payload's layout, so the
+	// re-parsed output's source positions are expected to differ from
+	// the input's. Only the decoded data needs to round-trip.
+	ignorePositions := cmp.Options{
+		cmpopts.IgnoreFields(joboptions.Value{}, "Position"),
+		cmpopts.IgnoreFields(joboptions.DictionaryEntry{}, "KeyPos", "ValuePos"),
+	}
+
+	for _, p := range validFiles {
+		t.Run(p, func(t *testing.T) {
+			data, err := os.ReadFile(p)
+			must(t, err, "read input file")
+
+			want, err := joboptions.Parse(data)
+			must(t, err, "parse input file")
+
+			enc, err := joboptions.Marshal(want)
+			must(t, err, "marshal parsed parameters")
+
+			got, err := joboptions.Parse(enc)
+			must(t, err, "parse marshaled output")
+
+			if diff := cmp.Diff(want, got, ignorePositions); diff != "" {
+				t.Errorf("Parse(Marshal(Parse(x))) mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMarshal_PreservesSourceOrder(t *testing.T) {
+	data := `<<
+  /Zebra true
+  /Apple false
+  /Mango 1
+>>
+setdistillerparams
+`
+
+	params, err := joboptions.Parse([]byte(data))
+	must(t, err, "parse input")
+
+	enc, err := joboptions.Marshal(params)
+	must(t, err, "marshal parsed parameters")
+
+	got, err := joboptions.Parse(enc)
+	must(t, err, "parse marshaled output")
+
+	want := []joboptions.Literal{"/Zebra", "/Apple", "/Mango"}
+	if diff := cmp.Diff(want, got["setdistillerparams"].Keys()); diff != "" {
+		t.Errorf("key order mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestMarshal_PreservesSourceOrderWithDuplicateKey(t *testing.T) {
+	// /Zebra appears twice, so Entries has one more item than Values.
+	// That shouldn't make the Encoder give up on source order for the
+	// other keys.
+	data := `<<
+  /Zebra true
+  /Apple false
+  /Zebra 1
+  /Mango 2
+>>
+setdistillerparams
+`
+
+	params, err := joboptions.Parse([]byte(data))
+	must(t, err, "parse input")
+
+	enc, err := joboptions.Marshal(params)
+	must(t, err, "marshal parsed parameters")
+
+	got, err := joboptions.Parse(enc)
+	must(t, err, "parse marshaled output")
+
+	want := []joboptions.Literal{"/Zebra", "/Apple", "/Mango"}
+	if diff := cmp.Diff(want, got["setdistillerparams"].Keys()); diff != "" {
+		t.Errorf("key order mismatch (-want +got):\n%s", diff)
+	}
+}
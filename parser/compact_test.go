@@ -0,0 +1,34 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestScanner_CompactBoundsBuffer verifies that a Scanner reading from an
+// io.Reader doesn't keep already-consumed bytes resident: once many
+// dictionaries have been scanned, the buffer should stay close to a single
+// chunk rather than growing with the number of dictionaries read.
+func TestScanner_CompactBoundsBuffer(t *testing.T) {
+	const dictionaries = 500
+
+	var b strings.Builder
+
+	for i := 0; i < dictionaries; i++ {
+		b.WriteString("<<\n  /Foo true\n>>\nsetdistillerparams\n")
+	}
+
+	s := NewReaderScanner(strings.NewReader(b.String()), ScanLimits{})
+
+	for s.Scan() {
+	}
+
+	if err := s.Err(); err != nil {
+		t.Fatalf("scan without errors: %v", err)
+	}
+
+	if len(s.buffer) > 2*defaultChunkSize {
+		t.Errorf("got buffer length %d, want it bounded near a chunk (%d)",
+			len(s.buffer), defaultChunkSize)
+	}
+}
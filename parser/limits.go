@@ -0,0 +1,54 @@
+package parser
+
+import "fmt"
+
+// LimitKind identifies which configured limit was exceeded while scanning or
+// parsing a joboptions payload.
+type LimitKind int
+
+const (
+	LimitUnknown LimitKind = iota
+	LimitMaxDepth
+	LimitMaxStringLength
+	LimitMaxBinaryLength
+	LimitMaxArrayElements
+	LimitMaxDictionaryEntries
+)
+
+func (k LimitKind) String() string {
+	switch k {
+	case LimitMaxDepth:
+		return "MaxDepth"
+	case LimitMaxStringLength:
+		return "MaxStringLength"
+	case LimitMaxBinaryLength:
+		return "MaxBinaryLength"
+	case LimitMaxArrayElements:
+		return "MaxArrayElements"
+	case LimitMaxDictionaryEntries:
+		return "MaxDictionaryEntries"
+	case LimitUnknown:
+		return "unknown"
+	default:
+		panic(fmt.Sprintf("unexpected LimitKind: %#v", k))
+	}
+}
+
+// LimitError is returned when a configured resource limit is exceeded while
+// scanning or parsing a joboptions payload.
+type LimitError struct {
+	Kind LimitKind
+	Line int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("line %d: exceeded %s limit", e.Line, e.Kind)
+}
+
+// ScanLimits bounds resource usage while scanning from a stream, guarding
+// against unbounded allocation from malicious or malformed input. A zero
+// value leaves the corresponding limit unbounded.
+type ScanLimits struct {
+	MaxStringLength int
+	MaxBinaryLength int
+}
@@ -16,6 +16,8 @@ func (tt TokenType) String() string {
 		return "binary"
 	case TypeBoolean:
 		return "boolean"
+	case TypeComment:
+		return "comment"
 	case TypeEndArray:
 		return "end_array"
 	case TypeEndDictionary:
@@ -51,11 +53,24 @@ const (
 	TypeIdentifier
 	TypeNumber
 	TypeBinary
+	TypeComment
 )
 
 type Token struct {
 	Type  TokenType
 	Value []byte
+	// Line is the 1-indexed line the token starts on.
+	Line int
+	// Column is the 1-indexed column, counted in bytes from the start of
+	// the line, the token starts at.
+	Column int
+	// Offset is the 0-indexed byte offset the token starts at.
+	Offset int
+}
+
+// Position returns the token's start position.
+func (t Token) Position() Position {
+	return Position{Line: t.Line, Column: t.Column, Offset: t.Offset}
 }
 
 func (t Token) String() string {
@@ -66,6 +81,7 @@ func (t Token) NewBinaryReader() io.Reader {
 	return hex.NewDecoder(bytes.NewReader(t.Value))
 }
 
+// NewScanner returns a Scanner over the full, already loaded, payload.
 func NewScanner(data []byte) *Scanner {
 	return &Scanner{
 		buffer: data,
@@ -73,10 +89,35 @@ func NewScanner(data []byte) *Scanner {
 	}
 }
 
+// defaultChunkSize is how much data NewReaderScanner reads from its
+// io.Reader at a time.
+const defaultChunkSize = 4096
+
+// NewReaderScanner returns a Scanner that reads from r in bounded chunks
+// instead of requiring the full payload to be preloaded into memory.
+func NewReaderScanner(r io.Reader, limits ScanLimits) *Scanner {
+	return &Scanner{
+		r:      r,
+		line:   1,
+		limits: limits,
+	}
+}
+
 type Scanner struct {
-	buffer []byte
-	offset int
-	line   int
+	// r is set when the Scanner is reading from an io.Reader in chunks.
+	// It is nil, and buffer holds the whole payload, when created with
+	// NewScanner.
+	r      io.Reader
+	limits ScanLimits
+
+	buffer    []byte
+	offset    int
+	line      int
+	lineStart int
+	// base is how many bytes have been permanently discarded from the
+	// front of buffer by compact, so Position.Offset can still report
+	// the byte's offset from the start of the payload.
+	base int
 
 	token Token
 	err   error
@@ -94,6 +135,13 @@ func (s *Scanner) Scan() bool {
 		return false
 	}
 
+	// The previous token, if any, has already been consumed by the
+	// caller, so it's safe to drop everything before the current offset
+	// now. This keeps a Scanner reading from an io.Reader from holding
+	// the whole stream in memory, instead of just delaying when it's
+	// all read in.
+	s.compact()
+
 	t := s.scan()
 
 	if t != nil {
@@ -128,6 +176,12 @@ func (s *Scanner) Token() Token {
 	return s.token
 }
 
+// Line returns the current 1-indexed line number, i.e. the line on which the
+// token most recently returned by Token ends.
+func (s *Scanner) Line() int {
+	return s.line
+}
+
 func (s *Scanner) Err() error {
 	if s.err == nil {
 		return nil
@@ -142,6 +196,51 @@ func (s *Scanner) scan() *Token {
 		return nil
 	}
 
+	// Make sure we have enough lookahead to match the longest fixed
+	// prefix ("false") even if it straddles a chunk boundary.
+	s.ensure(len(tFalse))
+
+	pos := s.pos()
+
+	t := s.scanToken()
+	if t == nil {
+		return nil
+	}
+
+	t.Line = pos.Line
+	t.Column = pos.Column
+	t.Offset = pos.Offset
+
+	return t
+}
+
+// pos returns the position of the byte at the scanner's current offset.
+func (s *Scanner) pos() Position {
+	return Position{
+		Line:   s.line,
+		Column: s.offset - s.lineStart + 1,
+		Offset: s.base + s.offset,
+	}
+}
+
+// compact discards the already-consumed prefix of buffer, i.e. everything
+// before offset, so a Scanner reading from an io.Reader doesn't keep
+// already-returned tokens resident in memory for the rest of the scan.
+// It's a no-op for a Scanner created with NewScanner, which has the whole
+// payload preloaded anyway.
+func (s *Scanner) compact() {
+	if s.r == nil || s.offset == 0 {
+		return
+	}
+
+	n := copy(s.buffer, s.buffer[s.offset:])
+	s.buffer = s.buffer[:n]
+	s.base += s.offset
+	s.lineStart -= s.offset
+	s.offset = 0
+}
+
+func (s *Scanner) scanToken() *Token {
 	next := s.buffer[s.offset:]
 
 	switch next[0] {
@@ -152,9 +251,11 @@ func (s *Scanner) scan() *Token {
 	case ']':
 		return s.advanceAndCapture(TypeEndArray, 1)
 	case '(':
-		return s.captureUntil(TypeString, ')')
+		return s.captureUntil(TypeString, ')', s.limits.MaxStringLength, LimitMaxStringLength)
 	case '-':
 		return s.captureUntilWs(TypeNumber)
+	case '%':
+		return s.captureComment()
 	}
 
 	switch {
@@ -175,7 +276,7 @@ func (s *Scanner) scan() *Token {
 	case next[0] >= '0' && next[0] <= '9':
 		return s.captureNumber()
 	case next[0] == '<':
-		return s.captureUntil(TypeBinary, '>')
+		return s.captureUntil(TypeBinary, '>', s.limits.MaxBinaryLength, LimitMaxBinaryLength)
 	default:
 		s.err = fmt.Errorf("unexpected token %q", next[0:1])
 
@@ -183,15 +284,66 @@ func (s *Scanner) scan() *Token {
 	}
 }
 
-func (s *Scanner) captureUntil(t TokenType, c byte) *Token {
+// fill reads another chunk from r, if any, appending it to buffer. It
+// returns false once r is exhausted (or the Scanner isn't reading from a
+// stream at all), at which point further fill calls are no-ops.
+func (s *Scanner) fill() bool {
+	if s.r == nil {
+		return false
+	}
+
+	buf := make([]byte, defaultChunkSize)
+
+	n, err := s.r.Read(buf)
+	if n > 0 {
+		s.buffer = append(s.buffer, buf[:n]...)
+	}
+
+	if err != nil {
+		s.r = nil
+
+		if err != io.EOF {
+			s.err = err
+		}
+	}
+
+	return n > 0
+}
+
+// ensure tries to grow the buffer, if needed, until at least n unconsumed
+// bytes are available. It gives up silently once the underlying reader is
+// exhausted, leaving callers to handle the short buffer as they would at
+// the end of a preloaded payload.
+func (s *Scanner) ensure(n int) {
+	for len(s.buffer)-s.offset < n {
+		if !s.fill() {
+			return
+		}
+	}
+}
+
+func (s *Scanner) captureUntil(t TokenType, c byte, maxLen int, kind LimitKind) *Token {
 	start := s.offset + 1
 
 	for {
 		s.offset++
 
-		if s.offset == len(s.buffer) {
-			s.err = fmt.Errorf("expected end of string: %w",
-				io.ErrUnexpectedEOF)
+		for s.offset >= len(s.buffer) {
+			if !s.fill() {
+				s.err = fmt.Errorf("expected end of string: %w",
+					io.ErrUnexpectedEOF)
+
+				return nil
+			}
+		}
+
+		if s.buffer[s.offset] == '\n' {
+			s.line++
+			s.lineStart = s.offset + 1
+		}
+
+		if maxLen > 0 && s.offset-start > maxLen {
+			s.err = &LimitError{Kind: kind, Line: s.line}
 
 			return nil
 		}
@@ -209,6 +361,40 @@ func (s *Scanner) captureUntil(t TokenType, c byte) *Token {
 	}
 }
 
+// captureComment reads a '%' comment up to but not including the end of the
+// line, so the Token's Value is the text following the '%'.
+func (s *Scanner) captureComment() *Token {
+	s.offset++ // skip the leading '%'
+
+	start := s.offset
+
+	for {
+		if s.offset >= len(s.buffer) {
+			if !s.fill() {
+				break
+			}
+
+			continue
+		}
+
+		if s.buffer[s.offset] == '\n' {
+			break
+		}
+
+		s.offset++
+	}
+
+	end := s.offset
+	if end > start && end <= len(s.buffer) && s.buffer[end-1] == '\r' {
+		end--
+	}
+
+	return &Token{
+		Type:  TypeComment,
+		Value: s.buffer[start:end],
+	}
+}
+
 func (s *Scanner) captureNumber() *Token {
 	dotPos := -1
 
@@ -217,8 +403,13 @@ func (s *Scanner) captureNumber() *Token {
 	for {
 		s.offset++
 
-		if s.offset == len(s.buffer) {
-			break
+		for s.offset >= len(s.buffer) {
+			if !s.fill() {
+				return &Token{
+					Type:  TypeNumber,
+					Value: s.buffer[start:s.offset],
+				}
+			}
 		}
 
 		c := s.buffer[s.offset]
@@ -257,7 +448,15 @@ func (s *Scanner) captureUntilWs(
 ) *Token {
 	start := s.offset
 
-	for s.offset < len(s.buffer) {
+	for {
+		if s.offset >= len(s.buffer) {
+			if !s.fill() {
+				break
+			}
+
+			continue
+		}
+
 		if ws[s.buffer[s.offset]] {
 			break
 		}
@@ -285,24 +484,25 @@ func (s *Scanner) advanceAndCapture(
 }
 
 func (s *Scanner) skipWS() bool {
-	if s.offset == len(s.buffer) {
-		return false
-	}
-
 	for {
+		if s.offset >= len(s.buffer) {
+			if !s.fill() {
+				return false
+			}
+
+			continue
+		}
+
 		if !ws[s.buffer[s.offset]] {
 			break
 		}
 
 		if s.buffer[s.offset] == '\n' {
 			s.line++
+			s.lineStart = s.offset + 1
 		}
 
 		s.offset++
-
-		if s.offset == len(s.buffer) {
-			return false
-		}
 	}
 
 	return true
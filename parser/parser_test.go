@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/ttab/joboptions/parser"
@@ -29,6 +30,102 @@ func TestScanner(t *testing.T) {
 	}
 }
 
+func TestScanner_Comments(t *testing.T) {
+	data := []byte("<<\n% a leading comment\n/Foo true %inline\n>>\n")
+
+	s := parser.NewScanner(data)
+
+	var got []parser.Token
+
+	for s.Scan() {
+		got = append(got, s.Token())
+	}
+
+	must(t, s.Err(), "scan without errors")
+
+	want := []parser.TokenType{
+		parser.TypeStartDictionary,
+		parser.TypeComment,
+		parser.TypeLiteral,
+		parser.TypeBoolean,
+		parser.TypeComment,
+		parser.TypeEndDictionary,
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d tokens, want %d", len(got), len(want))
+	}
+
+	for i, tt := range want {
+		if got[i].Type != tt {
+			t.Errorf("token %d: got %s, want %s", i, got[i].Type, tt)
+		}
+	}
+}
+
+func TestScanner_Position(t *testing.T) {
+	data := []byte("<<\n  /Foo true\n>>\n")
+
+	s := parser.NewScanner(data)
+
+	var got []parser.Token
+
+	for s.Scan() {
+		got = append(got, s.Token())
+	}
+
+	must(t, s.Err(), "scan without errors")
+
+	if len(got) != 4 {
+		t.Fatalf("got %d tokens, want 4", len(got))
+	}
+
+	literal := got[1]
+	if literal.Line != 2 || literal.Column != 3 || literal.Offset != 5 {
+		t.Errorf("got literal position %+v, want line 2, column 3, offset 5",
+			literal.Position())
+	}
+
+	boolean := got[2]
+	if boolean.Line != 2 || boolean.Column != 8 {
+		t.Errorf("got boolean position %+v, want line 2, column 8",
+			boolean.Position())
+	}
+}
+
+func TestScanner_ReaderAcrossManyDictionaries(t *testing.T) {
+	var want []string
+
+	var b strings.Builder
+
+	for i := 0; i < 200; i++ {
+		b.WriteString("<<\n  /Foo true\n>>\nsetdistillerparams\n")
+		want = append(want, "setdistillerparams")
+	}
+
+	s := parser.NewReaderScanner(strings.NewReader(b.String()), parser.ScanLimits{})
+
+	var names []string
+
+	for s.Scan() {
+		if s.Token().Type == parser.TypeIdentifier {
+			names = append(names, s.Token().String())
+		}
+	}
+
+	must(t, s.Err(), "scan without errors")
+
+	if len(names) != len(want) {
+		t.Fatalf("got %d dictionary names, want %d", len(names), len(want))
+	}
+
+	for i, name := range want {
+		if names[i] != name {
+			t.Errorf("dictionary %d: got %q, want %q", i, names[i], name)
+		}
+	}
+}
+
 func must(t *testing.T, err error, format string, a ...any) {
 	t.Helper()
 
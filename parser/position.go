@@ -0,0 +1,12 @@
+package parser
+
+// Position identifies a location in a joboptions payload.
+type Position struct {
+	// Line is the 1-indexed line the position falls on.
+	Line int `json:"line"`
+	// Column is the 1-indexed column, counted in bytes from the start of
+	// the line.
+	Column int `json:"column"`
+	// Offset is the 0-indexed byte offset from the start of the payload.
+	Offset int `json:"offset"`
+}
@@ -0,0 +1,53 @@
+package joboptions_test
+
+import (
+	"testing"
+
+	"github.com/ttab/joboptions"
+)
+
+func TestParse_Position(t *testing.T) {
+	data := `<<
+  /AntiAliasGrayImages true
+  /ColorSettingsFile (sRGB)
+>>
+setdistillerparams
+`
+
+	params, err := joboptions.Parse([]byte(data))
+	must(t, err, "parse input")
+
+	d, ok := params["setdistillerparams"]
+	if !ok {
+		t.Fatalf("missing setdistillerparams dictionary")
+	}
+
+	keys := d.Keys()
+	want := []joboptions.Literal{"/AntiAliasGrayImages", "/ColorSettingsFile"}
+
+	if len(keys) != len(want) {
+		t.Fatalf("got %d keys, want %d", len(keys), len(want))
+	}
+
+	for i, k := range want {
+		if keys[i] != k {
+			t.Errorf("key %d: got %q, want %q", i, keys[i], k)
+		}
+	}
+
+	first := d.Entries[0]
+	if first.KeyPos.Line != 2 || first.KeyPos.Column != 3 {
+		t.Errorf("got key position %+v, want line 2, column 3", first.KeyPos)
+	}
+
+	wantValueColumn := first.KeyPos.Column + len("/AntiAliasGrayImages") + 1
+
+	if first.ValuePos.Line != 2 || first.ValuePos.Column != wantValueColumn {
+		t.Errorf("got value position %+v, want line 2, column %d", first.ValuePos, wantValueColumn)
+	}
+
+	second := d.Entries[1]
+	if second.KeyPos.Line != 3 {
+		t.Errorf("got second key line %d, want 3", second.KeyPos.Line)
+	}
+}
@@ -0,0 +1,518 @@
+package joboptions
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// UnmarshalerJobOptions is implemented by types that want to decode their
+// own representation from a joboptions Value, bypassing the built-in
+// struct-tag binding.
+type UnmarshalerJobOptions interface {
+	UnmarshalJobOptions(Value) error
+}
+
+// MarshalerJobOptions is implemented by types that want to encode their own
+// representation as a joboptions Value, bypassing the built-in struct-tag
+// binding.
+type MarshalerJobOptions interface {
+	MarshalJobOptions() (Value, error)
+}
+
+// UnmarshalError describes a failure to bind a parsed joboptions value onto
+// a Go value via Unmarshal or Decoder.Decode. Marshal also returns it when a
+// struct field can't be encoded.
+type UnmarshalError struct {
+	// Dictionary is the name of the top-level parameter dictionary being
+	// decoded, e.g. "setdistillerparams".
+	Dictionary string
+	// Path is the chain of dictionary keys and array indices, outermost
+	// first, leading to the value that failed to decode.
+	Path []string
+	// Line is the source line of the value that failed to decode, or 0
+	// if there is none: when the failure is on a top-level dictionary
+	// itself, or when the error comes from Marshal.
+	Line int
+	Err  error
+}
+
+func (e *UnmarshalError) Error() string {
+	path := strings.Join(e.Path, ".")
+
+	if e.Line > 0 {
+		return fmt.Sprintf("joboptions: dictionary %q, key %q, line %d: %s",
+			e.Dictionary, path, e.Line, e.Err)
+	}
+
+	return fmt.Sprintf("joboptions: dictionary %q, key %q: %s",
+		e.Dictionary, path, e.Err)
+}
+
+func (e *UnmarshalError) Unwrap() error {
+	return e.Err
+}
+
+// wrapPathError prepends key to a nested *UnmarshalError's Path, keeping
+// its Line (the innermost, and therefore most useful, one), or wraps err in
+// a new *UnmarshalError if it isn't one yet.
+func wrapPathError(key string, line int, err error) error {
+	var ue *UnmarshalError
+	if errors.As(err, &ue) {
+		ue.Path = append([]string{key}, ue.Path...)
+
+		return ue
+	}
+
+	return &UnmarshalError{Path: []string{key}, Line: line, Err: err}
+}
+
+// Unmarshal parses data and binds the resulting Parameters onto v, which
+// must be a non-nil pointer to a struct. Struct fields are bound using a
+// `joboptions:"name"` tag identifying the top-level dictionary name; fields
+// without a tag use their Go name.
+func Unmarshal(data []byte, v any) error {
+	params, err := Parse(data)
+	if err != nil {
+		return err
+	}
+
+	return decodeParameters(params, v)
+}
+
+var valueType = reflect.TypeOf(Value{})
+
+var literalType = reflect.TypeOf(Literal(""))
+
+type tagOptions struct {
+	name      string
+	omitempty bool
+	utf16     bool
+}
+
+func parseTag(raw string) tagOptions {
+	parts := strings.Split(raw, ",")
+	opts := tagOptions{name: parts[0]}
+
+	for _, p := range parts[1:] {
+		switch p {
+		case "omitempty":
+			opts.omitempty = true
+		case "utf16":
+			opts.utf16 = true
+		}
+	}
+
+	return opts
+}
+
+// fieldName returns the joboptions key the struct field is bound to, and
+// whether it should be skipped entirely.
+func fieldName(field reflect.StructField, tag tagOptions) (string, bool) {
+	if tag.name == "-" {
+		return "", true
+	}
+
+	if tag.name != "" {
+		return tag.name, false
+	}
+
+	return field.Name, false
+}
+
+// dictKey turns a plain field/tag name into the Literal used as a
+// Dictionary key, adding the leading slash that's part of Literal's wire
+// representation.
+func dictKey(name string) Literal {
+	return Literal("/" + name)
+}
+
+func decodeParameters(params Parameters, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("joboptions: Unmarshal target must be a non-nil pointer")
+	}
+
+	elem := rv.Elem()
+	if elem.Kind() != reflect.Struct {
+		return fmt.Errorf("joboptions: Unmarshal target must point to a struct")
+	}
+
+	t := elem.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := parseTag(field.Tag.Get("joboptions"))
+
+		name, skip := fieldName(field, tag)
+		if skip {
+			continue
+		}
+
+		dict, ok := params[name]
+		if !ok {
+			continue
+		}
+
+		if err := decodeDictionary(dict, elem.Field(i), tag); err != nil {
+			var ue *UnmarshalError
+			if errors.As(err, &ue) {
+				ue.Dictionary = name
+
+				return ue
+			}
+
+			return &UnmarshalError{Dictionary: name, Err: err}
+		}
+	}
+
+	return nil
+}
+
+func decodeDictionary(d Dictionary, rv reflect.Value, tag tagOptions) error {
+	rv = allocAndDeref(rv)
+
+	switch rv.Kind() {
+	case reflect.Struct:
+		t := rv.Type()
+
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			ftag := parseTag(field.Tag.Get("joboptions"))
+
+			key, skip := fieldName(field, ftag)
+			if skip {
+				continue
+			}
+
+			value, ok := d.Values[dictKey(key)]
+			if !ok {
+				continue
+			}
+
+			if err := decodeValue(value, rv.Field(i), ftag); err != nil {
+				return wrapPathError(key, value.Position.Line, err)
+			}
+		}
+
+		return nil
+	case reflect.Map:
+		return decodeMapFromDictionary(d, rv)
+	default:
+		return fmt.Errorf("cannot unmarshal a dictionary into %s", rv.Kind())
+	}
+}
+
+func decodeMapFromDictionary(d Dictionary, rv reflect.Value) error {
+	mt := rv.Type()
+	if mt.Key().Kind() != reflect.String {
+		return fmt.Errorf("map key type %s must be a string or joboptions.Literal", mt.Key())
+	}
+
+	if rv.IsNil() {
+		rv.Set(reflect.MakeMap(mt))
+	}
+
+	for k, v := range d.Values {
+		elem := reflect.New(mt.Elem()).Elem()
+
+		if err := decodeValue(v, elem, tagOptions{}); err != nil {
+			return wrapPathError(string(k), v.Position.Line, err)
+		}
+
+		rv.SetMapIndex(reflect.ValueOf(string(k)).Convert(mt.Key()), elem)
+	}
+
+	return nil
+}
+
+func decodeValue(v Value, rv reflect.Value, tag tagOptions) error {
+	rv = allocAndDeref(rv)
+
+	if rv.Type() == valueType {
+		rv.Set(reflect.ValueOf(v))
+		return nil
+	}
+
+	if rv.CanAddr() {
+		if u, ok := rv.Addr().Interface().(UnmarshalerJobOptions); ok {
+			return u.UnmarshalJobOptions(v)
+		}
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		switch v.Type {
+		case ValueString:
+			rv.SetString(v.String)
+		case ValueLiteral:
+			rv.SetString(string(v.Literal))
+		case ValueBinary:
+			if !tag.utf16 {
+				return fmt.Errorf("cannot unmarshal binary into string without the utf16 tag option")
+			}
+
+			s, err := v.StringFromUTF16()
+			if err != nil {
+				return err
+			}
+
+			rv.SetString(s)
+		default:
+			return fmt.Errorf("cannot unmarshal %v into string", v.Type)
+		}
+	case reflect.Bool:
+		if v.Type != ValueBoolean {
+			return fmt.Errorf("cannot unmarshal %v into bool", v.Type)
+		}
+
+		rv.SetBool(v.Boolean)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Type != ValueInteger {
+			return fmt.Errorf("cannot unmarshal %v into %s", v.Type, rv.Kind())
+		}
+
+		rv.SetInt(int64(v.Integer))
+	case reflect.Float32, reflect.Float64:
+		switch v.Type {
+		case ValueFloat:
+			rv.SetFloat(v.Float)
+		case ValueInteger:
+			rv.SetFloat(float64(v.Integer))
+		default:
+			return fmt.Errorf("cannot unmarshal %v into float64", v.Type)
+		}
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			if v.Type != ValueBinary {
+				return fmt.Errorf("cannot unmarshal %v into []byte", v.Type)
+			}
+
+			rv.SetBytes(append([]byte(nil), v.Binary...))
+
+			return nil
+		}
+
+		if v.Type != ValueArray {
+			return fmt.Errorf("cannot unmarshal %v into a slice", v.Type)
+		}
+
+		out := reflect.MakeSlice(rv.Type(), len(v.Array), len(v.Array))
+
+		for i, elem := range v.Array {
+			if err := decodeValue(elem, out.Index(i), tagOptions{}); err != nil {
+				return wrapPathError(fmt.Sprintf("[%d]", i), elem.Position.Line, err)
+			}
+		}
+
+		rv.Set(out)
+	case reflect.Map:
+		if v.Type != ValueDictionary {
+			return fmt.Errorf("cannot unmarshal %v into a map", v.Type)
+		}
+
+		return decodeMapFromDictionary(v.Dictionary, rv)
+	case reflect.Struct:
+		if v.Type != ValueDictionary {
+			return fmt.Errorf("cannot unmarshal %v into a struct", v.Type)
+		}
+
+		return decodeDictionary(v.Dictionary, rv, tag)
+	default:
+		return fmt.Errorf("unsupported target kind %s", rv.Kind())
+	}
+
+	return nil
+}
+
+// allocAndDeref follows rv through any pointers, allocating as needed, and
+// returns the pointed-to value.
+func allocAndDeref(rv reflect.Value) reflect.Value {
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+
+		rv = rv.Elem()
+	}
+
+	return rv
+}
+
+// structToParameters builds a Parameters set from v using the same
+// joboptions struct tags as Unmarshal.
+func structToParameters(v any) (Parameters, error) {
+	rv := reflect.ValueOf(v)
+
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil, fmt.Errorf("joboptions: Marshal target is a nil pointer")
+		}
+
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("joboptions: Marshal does not support %s", rv.Kind())
+	}
+
+	t := rv.Type()
+	params := make(Parameters)
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := parseTag(field.Tag.Get("joboptions"))
+
+		name, skip := fieldName(field, tag)
+		if skip {
+			continue
+		}
+
+		fv := rv.Field(i)
+		if tag.omitempty && fv.IsZero() {
+			continue
+		}
+
+		d, err := encodeDictionary(fv, tag)
+		if err != nil {
+			return nil, &UnmarshalError{Dictionary: name, Err: err}
+		}
+
+		params[name] = d
+	}
+
+	return params, nil
+}
+
+func encodeDictionary(fv reflect.Value, tag tagOptions) (Dictionary, error) {
+	for fv.Kind() == reflect.Pointer {
+		if fv.IsNil() {
+			return Dictionary{}, nil
+		}
+
+		fv = fv.Elem()
+	}
+
+	if fv.Kind() != reflect.Struct {
+		return Dictionary{}, fmt.Errorf("field must be a struct to encode as a dictionary, got %s", fv.Kind())
+	}
+
+	t := fv.Type()
+	d := Dictionary{Values: make(Values)}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		ftag := parseTag(field.Tag.Get("joboptions"))
+
+		key, skip := fieldName(field, ftag)
+		if skip {
+			continue
+		}
+
+		elemValue := fv.Field(i)
+		if ftag.omitempty && elemValue.IsZero() {
+			continue
+		}
+
+		value, err := encodeValue(elemValue, ftag)
+		if err != nil {
+			return Dictionary{}, fmt.Errorf("key %q: %w", key, err)
+		}
+
+		d.Values[dictKey(key)] = value
+	}
+
+	return d, nil
+}
+
+func encodeValue(rv reflect.Value, tag tagOptions) (Value, error) {
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return Value{}, nil
+		}
+
+		rv = rv.Elem()
+	}
+
+	if rv.Type() == valueType {
+		return rv.Interface().(Value), nil //nolint:forcetypeassert
+	}
+
+	if rv.CanInterface() {
+		if m, ok := rv.Interface().(MarshalerJobOptions); ok {
+			return m.MarshalJobOptions()
+		}
+	}
+
+	if rv.Type() == literalType {
+		return Value{Type: ValueLiteral, Literal: Literal(rv.String())}, nil
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		return Value{Type: ValueString, String: rv.String()}, nil
+	case reflect.Bool:
+		return Value{Type: ValueBoolean, Boolean: rv.Bool()}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return Value{Type: ValueInteger, Integer: int(rv.Int())}, nil
+	case reflect.Float32, reflect.Float64:
+		return Value{Type: ValueFloat, Float: rv.Float()}, nil
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return Value{Type: ValueBinary, Binary: append([]byte(nil), rv.Bytes()...)}, nil
+		}
+
+		arr := make([]Value, rv.Len())
+
+		for i := range arr {
+			elem, err := encodeValue(rv.Index(i), tagOptions{})
+			if err != nil {
+				return Value{}, fmt.Errorf("index %d: %w", i, err)
+			}
+
+			arr[i] = elem
+		}
+
+		return Value{Type: ValueArray, Array: arr}, nil
+	case reflect.Map:
+		d := Dictionary{Values: make(Values)}
+
+		iter := rv.MapRange()
+		for iter.Next() {
+			elem, err := encodeValue(iter.Value(), tagOptions{})
+			if err != nil {
+				return Value{}, fmt.Errorf("key %q: %w", iter.Key(), err)
+			}
+
+			d.Values[Literal(fmt.Sprint(iter.Key().Interface()))] = elem
+		}
+
+		return Value{Type: ValueDictionary, Dictionary: d}, nil
+	case reflect.Struct:
+		d, err := encodeDictionary(rv, tag)
+		if err != nil {
+			return Value{}, err
+		}
+
+		return Value{Type: ValueDictionary, Dictionary: d}, nil
+	default:
+		return Value{}, fmt.Errorf("unsupported field kind %s", rv.Kind())
+	}
+}
@@ -0,0 +1,120 @@
+package joboptions_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ttab/joboptions"
+)
+
+type distillerParams struct {
+	AntiAliasGrayImages bool   `joboptions:"AntiAliasGrayImages"`
+	ColorSettingsFile   string `joboptions:"ColorSettingsFile"`
+	CompressPages       bool   `joboptions:"CompressPages,omitempty"`
+}
+
+type pageDevice struct {
+	PageSize []int `joboptions:"PageSize"`
+}
+
+type settings struct {
+	SetDistillerParams distillerParams `joboptions:"setdistillerparams"`
+	SetPageDevice      pageDevice      `joboptions:"setpagedevice"`
+}
+
+func TestUnmarshal_Struct(t *testing.T) {
+	data := `<<
+  /AntiAliasGrayImages true
+  /ColorSettingsFile (sRGB)
+>>
+setdistillerparams
+<<
+  /PageSize [612 792]
+>>
+setpagedevice
+`
+
+	var got settings
+
+	err := joboptions.Unmarshal([]byte(data), &got)
+	must(t, err, "unmarshal")
+
+	want := settings{
+		SetDistillerParams: distillerParams{
+			AntiAliasGrayImages: true,
+			ColorSettingsFile:   "sRGB",
+		},
+		SetPageDevice: pageDevice{
+			PageSize: []int{612, 792},
+		},
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Unmarshal() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestMarshal_Struct(t *testing.T) {
+	in := settings{
+		SetDistillerParams: distillerParams{
+			AntiAliasGrayImages: true,
+			ColorSettingsFile:   "sRGB",
+		},
+		SetPageDevice: pageDevice{
+			PageSize: []int{612, 792},
+		},
+	}
+
+	data, err := joboptions.Marshal(&in)
+	must(t, err, "marshal")
+
+	var got settings
+
+	err = joboptions.Unmarshal(data, &got)
+	must(t, err, "unmarshal round-tripped data")
+
+	if got.SetDistillerParams != in.SetDistillerParams {
+		t.Errorf("got %+v, want %+v", got.SetDistillerParams, in.SetDistillerParams)
+	}
+}
+
+func TestUnmarshal_NotAPointer(t *testing.T) {
+	err := joboptions.Unmarshal([]byte("<<\n>>\nsetdistillerparams\n"), settings{})
+	if err == nil {
+		t.Fatal("expected an error when passing a non-pointer target")
+	}
+}
+
+func TestUnmarshal_ErrorPath(t *testing.T) {
+	data := `<<
+  /PageSize [612 (not-a-number)]
+>>
+setpagedevice
+`
+
+	var got settings
+
+	err := joboptions.Unmarshal([]byte(data), &got)
+	if err == nil {
+		t.Fatal("expected an error when a slice element has the wrong type")
+	}
+
+	var ue *joboptions.UnmarshalError
+	if !errors.As(err, &ue) {
+		t.Fatalf("got error %v, want an *UnmarshalError", err)
+	}
+
+	if ue.Dictionary != "setpagedevice" {
+		t.Errorf("got Dictionary %q, want %q", ue.Dictionary, "setpagedevice")
+	}
+
+	wantPath := []string{"PageSize", "[1]"}
+	if diff := cmp.Diff(wantPath, ue.Path); diff != "" {
+		t.Errorf("Path mismatch (-want +got):\n%s", diff)
+	}
+
+	if ue.Line != 2 {
+		t.Errorf("got Line %d, want 2", ue.Line)
+	}
+}